@@ -20,16 +20,19 @@ type RTDevices struct {
 }
 
 type RTDevice struct {
-	Name              string `json:"name,omitempty"`
-	Device            string `json:"device,omitempty"`
-	Psize             int    `json:"psize,omitempty"`
-	VerifyChid        int    `json:"verify_chid"`
-	Journal           string `json:"journal,omitempty"`
-	Metadata          string `json:"metadata,omitempty"`
-	Bcache            int    `json:"bcache,omitempty"`
-	BcacheWritearound int    `json:"bcache_writearound"`
-	PlevelOverride    int    `json:"plevel_override,omitempty"`
-	Sync              int    `json:"sync"`
+	Name                   string `json:"name,omitempty"`
+	Device                 string `json:"device,omitempty"`
+	Psize                  int    `json:"psize,omitempty"`
+	VerifyChid             int    `json:"verify_chid"`
+	Journal                string `json:"journal,omitempty"`
+	Metadata               string `json:"metadata,omitempty"`
+	Bcache                 int    `json:"bcache,omitempty"`
+	BcacheWritearound      int    `json:"bcache_writearound"`
+	BcacheBlockSize        int    `json:"bcache_block_size,omitempty"`
+	BcacheWritebackPercent int    `json:"bcache_writeback_percent,omitempty"`
+	BcacheSequentialCutoff int    `json:"bcache_sequential_cutoff,omitempty"`
+	PlevelOverride         int    `json:"plevel_override,omitempty"`
+	Sync                   int    `json:"sync"`
 }
 
 type RtlfsDevices struct {
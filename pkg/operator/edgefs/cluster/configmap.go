@@ -168,3 +168,26 @@ func (c *cluster) createClusterConfigMap(nodes []rookalpha.Node, deploymentConfi
 
 	return nil
 }
+
+// getDeployedRTDevices returns the RTDevices of each node from the existing cluster ConfigMap,
+// an empty map is returned if the cluster isn't deployed yet
+func (c *cluster) getDeployedRTDevices() map[string][]edgefsv1alpha1.RTDevice {
+	deployed := make(map[string][]edgefsv1alpha1.RTDevice)
+	configMap, err := c.context.Clientset.CoreV1().ConfigMaps(c.Namespace).Get(configName, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Warningf("failed to get ConfigMap %s: %v", configName, err)
+		}
+		return deployed
+	}
+
+	cm := make(map[string]edgefsv1alpha1.SetupNode)
+	if err := json.Unmarshal([]byte(configMap.Data["nesetup"]), &cm); err != nil {
+		logger.Warningf("failed to parse ConfigMap %s: %v", configName, err)
+		return deployed
+	}
+	for nodeName, nodeConfig := range cm {
+		deployed[nodeName] = nodeConfig.Rtrd.Devices
+	}
+	return deployed
+}
@@ -18,10 +18,15 @@ limitations under the License.
 package config
 
 import (
+	"fmt"
+	"math"
+	"math/big"
 	"strconv"
+	"strings"
 
 	"github.com/coreos/pkg/capnslog"
 	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 const (
@@ -38,13 +43,23 @@ func GetConfigStoreName(nodeName string) string {
 const (
 	RtVerifyChidKey       = "rtVerifyChid"
 	MaxSizeGB             = "maxSizeGB"
+	MaxSizeKey            = "maxSize"
 	LmdbPageSizeKey       = "lmdbPageSize"
 	UseBcacheKey          = "useBCache"
 	UseBcacheWBKey        = "useBCacheWB"
+	BcacheBlockSizeKey    = "bcacheBlockSize"
+	BcacheWBPercentKey    = "bcacheWritebackPercent"
+	BcacheSeqCutoffKey    = "bcacheSequentialCutoff"
 	UseMetadataMaskKey    = "useMetadataMask"
 	UseMetadataOffloadKey = "useMetadataOffload"
 	UseAllSSDKey          = "useAllSSD"
+	UseNVMeForMetadataKey = "useNVMeForMetadata"
 	RtPlevelOverrideKey   = "rtPLevelOverride"
+	RtHDDsPerSSDKey       = "rtHDDsPerSSD"
+	RtReserveSSDsKey      = "rtReserveSSDs"
+	RtJournalSSDCountKey  = "rtJournalSSDCount"
+	RtIncludeDevicesKey   = "rtIncludeDevices"
+	RtExcludeDevicesKey   = "rtExcludeDevices"
 	SyncKey               = "sync"
 	ZoneKey               = "zone"
 )
@@ -60,14 +75,32 @@ type StoreConfig struct {
 	UseBCache bool `json:"useBCache,omitempty"`
 	// enable write back cache
 	UseBCacheWB bool `json:"useBCacheWB,omitempty"`
+	// if > 0, bcache block size in bytes
+	BCacheBlockSize int `json:"bcacheBlockSize,omitempty"`
+	// if > 0, bcache writeback percent [1:100], only used with write back cache
+	BCacheWritebackPercent int `json:"bcacheWritebackPercent,omitempty"`
+	// if > 0, bcache sequential IO cutoff in bytes
+	BCacheSequentialCutoff int `json:"bcacheSequentialCutoff,omitempty"`
 	// what guts needs to go to SSD and what not
 	UseMetadataMask string `json:"useMetadataMask,omitempty"`
 	// when useAllSSD is false, enable metadata offload on SSD
 	UseMetadataOffload bool `json:"useMetadataOffload,omitempty"`
 	// only look for SSD/NVMe
 	UseAllSSD bool `json:"useAllSSD,omitempty"`
+	// when useMetadataOffload is true, use NVMe devices only for journal/metadata, SATA SSDs become data devices
+	UseNVMeForMetadata bool `json:"useNVMeForMetadata,omitempty"`
 	// if > 0, override automatic partitioning numbering logic
 	RtPLevelOverride int `json:"rtPLevelOverride,omitempty"`
+	// if > 0, max number of HDDs backed by each SSD in hybrid mode, unused SSDs are left out
+	RtHDDsPerSSD int `json:"rtHDDsPerSSD,omitempty"`
+	// if > 0, number of SSDs used as journals in hybrid mode, remaining SSDs become data devices
+	RtJournalSSDCount int `json:"rtJournalSSDCount,omitempty"`
+	// when useMetadataOffload and useAllSSD are false, do not use SSDs as data devices
+	RtReserveSSDs bool `json:"rtReserveSSDs,omitempty"`
+	// if not empty, only these device names or devlinks are used, even if not empty or partitioned
+	RtIncludeDevices []string `json:"rtIncludeDevices,omitempty"`
+	// device names or devlinks never used, wins over RtIncludeDevices
+	RtExcludeDevices []string `json:"rtExcludeDevices,omitempty"`
 	// sync cluster option [0:3]
 	Sync int `json:"sync"`
 	// apply edgefs cluster zones id to whole cluster or node if zone value > 0
@@ -76,16 +109,23 @@ type StoreConfig struct {
 
 func DefaultStoreConfig() StoreConfig {
 	return StoreConfig{
-		RtVerifyChid:       1,
-		LmdbPageSize:       16384,
-		UseBCache:          false,
-		UseBCacheWB:        false,
-		UseMetadataMask:    "0xff",
-		UseMetadataOffload: false,
-		UseAllSSD:          false,
-		RtPLevelOverride:   0,
-		Sync:               1,
-		Zone:               0,
+		RtVerifyChid:           1,
+		LmdbPageSize:           16384,
+		UseBCache:              false,
+		UseBCacheWB:            false,
+		BCacheBlockSize:        0,
+		BCacheWritebackPercent: 0,
+		BCacheSequentialCutoff: 0,
+		UseMetadataMask:        "0xff",
+		UseMetadataOffload:     false,
+		UseAllSSD:              false,
+		UseNVMeForMetadata:     false,
+		RtPLevelOverride:       0,
+		RtHDDsPerSSD:           0,
+		RtReserveSSDs:          false,
+		RtJournalSSDCount:      0,
+		Sync:                   1,
+		Zone:                   0,
 	}
 }
 
@@ -97,7 +137,13 @@ var validLmbdPageSize = map[int]bool{
 }
 
 func ToStoreConfig(config map[string]string) StoreConfig {
-	storeConfig := DefaultStoreConfig()
+	return ApplyStoreConfig(DefaultStoreConfig(), config)
+}
+
+// ApplyStoreConfig returns a copy of the base StoreConfig with the given config keys applied,
+// settings not present in config are inherited from the base
+func ApplyStoreConfig(base StoreConfig, config map[string]string) StoreConfig {
+	storeConfig := base
 	for k, v := range config {
 		switch k {
 		case RtVerifyChidKey:
@@ -109,30 +155,86 @@ func ToStoreConfig(config map[string]string) StoreConfig {
 			}
 		case MaxSizeGB:
 			value := convertToUint64IgnoreErr(v)
-			if value > 0 {
+			if _, ok := config[MaxSizeKey]; ok {
+				logger.Warningf("'MaxSizeGB' value %v ignored, 'maxSize' takes precedence", value)
+			} else if value > 0 {
 				storeConfig.MaxSize = value * 1024 * 1024 * 1024
 			} else {
 				logger.Warningf("Incorrect 'MaxSizeGB' value %v ignored", value)
 			}
+		case MaxSizeKey:
+			value, err := ParseSize(v)
+			if err != nil {
+				logger.Warningf("Incorrect 'maxSize' value ignored: %v", err)
+			} else if value > 0 {
+				storeConfig.MaxSize = uint64(value)
+			} else {
+				logger.Warningf("Incorrect 'maxSize' value %d ignored", value)
+			}
 		case LmdbPageSizeKey:
-			value := convertToIntIgnoreErr(v)
-			if validLmbdPageSize[value] {
+			value, err := ParseSize(v)
+			if err != nil {
+				logger.Warningf("Incorrect 'lmdbPageSize' value ignored: %v", err)
+			} else if validLmbdPageSize[value] {
 				storeConfig.LmdbPageSize = value
 			} else {
 				logger.Warningf("Incorrect 'lmdbPageSize' value %d ignored", value)
 			}
+		case RtJournalSSDCountKey:
+			value := convertToIntIgnoreErr(v)
+			if value >= 0 {
+				storeConfig.RtJournalSSDCount = value
+			} else {
+				logger.Warningf("Incorrect 'rtJournalSSDCount' value %d ignored", value)
+			}
+		case RtReserveSSDsKey:
+			storeConfig.RtReserveSSDs = convertToBoolIgnoreErr(v)
+		case RtIncludeDevicesKey:
+			storeConfig.RtIncludeDevices = convertToStringSlice(v)
+		case RtExcludeDevicesKey:
+			storeConfig.RtExcludeDevices = convertToStringSlice(v)
 		case UseBcacheKey:
 			storeConfig.UseBCache = convertToBoolIgnoreErr(v)
 		case UseBcacheWBKey:
 			storeConfig.UseBCacheWB = convertToBoolIgnoreErr(v)
+		case BcacheBlockSizeKey:
+			value, err := ParseSize(v)
+			if err != nil {
+				logger.Warningf("Incorrect 'bcacheBlockSize' value ignored: %v", err)
+			} else {
+				storeConfig.BCacheBlockSize = value
+			}
+		case BcacheWBPercentKey:
+			value := convertToIntIgnoreErr(v)
+			if value >= 0 && value <= 100 {
+				storeConfig.BCacheWritebackPercent = value
+			} else {
+				logger.Warningf("Incorrect 'bcacheWritebackPercent' value %d ignored", value)
+			}
+		case BcacheSeqCutoffKey:
+			value, err := ParseSize(v)
+			if err != nil {
+				logger.Warningf("Incorrect 'bcacheSequentialCutoff' value ignored: %v", err)
+			} else {
+				storeConfig.BCacheSequentialCutoff = value
+			}
 		case UseMetadataMaskKey:
 			storeConfig.UseMetadataMask = v
 		case UseMetadataOffloadKey:
 			storeConfig.UseMetadataOffload = convertToBoolIgnoreErr(v)
 		case UseAllSSDKey:
 			storeConfig.UseAllSSD = convertToBoolIgnoreErr(v)
+		case UseNVMeForMetadataKey:
+			storeConfig.UseNVMeForMetadata = convertToBoolIgnoreErr(v)
 		case RtPlevelOverrideKey:
 			storeConfig.RtPLevelOverride = convertToIntIgnoreErr(v)
+		case RtHDDsPerSSDKey:
+			value := convertToIntIgnoreErr(v)
+			if value >= 0 {
+				storeConfig.RtHDDsPerSSD = value
+			} else {
+				logger.Warningf("Incorrect 'rtHDDsPerSSD' value %d ignored", value)
+			}
 		case SyncKey:
 			value := convertToIntIgnoreErr(v)
 			if value >= 0 && value <= 3 {
@@ -152,6 +254,113 @@ func ToStoreConfig(config map[string]string) StoreConfig {
 	return storeConfig
 }
 
+// nodeOnlyKeys define the node's disk layout or rtlfs and zone settings, these can't be set per device
+var nodeOnlyKeys = []string{
+	UseAllSSDKey,
+	UseMetadataOffloadKey,
+	UseNVMeForMetadataKey,
+	RtHDDsPerSSDKey,
+	RtJournalSSDCountKey,
+	RtReserveSSDsKey,
+	RtIncludeDevicesKey,
+	RtExcludeDevicesKey,
+	MaxSizeGB,
+	MaxSizeKey,
+	ZoneKey,
+}
+
+// ValidateDeviceConfig checks device level config settings for keys which only apply to the whole node
+func ValidateDeviceConfig(config map[string]string) error {
+	for _, key := range nodeOnlyKeys {
+		if _, ok := config[key]; ok {
+			return fmt.Errorf("'%s' can't be set in device config, it only applies to the whole node", key)
+		}
+	}
+	return nil
+}
+
+// ValidateStoreConfig checks StoreConfig for contradictory settings
+func ValidateStoreConfig(storeConfig *StoreConfig) error {
+	if storeConfig == nil {
+		return fmt.Errorf("no pointer to StoreConfig provided")
+	}
+	if storeConfig.RtVerifyChid < 0 || storeConfig.RtVerifyChid > 2 {
+		return fmt.Errorf("'%s' value %d is out of range [0:2]", RtVerifyChidKey, storeConfig.RtVerifyChid)
+	}
+	if storeConfig.LmdbPageSize <= 0 || storeConfig.LmdbPageSize&(storeConfig.LmdbPageSize-1) != 0 {
+		return fmt.Errorf("'%s' value %d is not a power of two", LmdbPageSizeKey, storeConfig.LmdbPageSize)
+	}
+	if storeConfig.Sync < 0 || storeConfig.Sync > 3 {
+		return fmt.Errorf("'%s' value %d is out of range [0:3]", SyncKey, storeConfig.Sync)
+	}
+	if storeConfig.UseBCacheWB && !storeConfig.UseBCache {
+		return fmt.Errorf("'%s' requires '%s' to be enabled", UseBcacheWBKey, UseBcacheKey)
+	}
+	if storeConfig.BCacheBlockSize < 0 || storeConfig.BCacheBlockSize&(storeConfig.BCacheBlockSize-1) != 0 {
+		return fmt.Errorf("'%s' value %d is not a power of two", BcacheBlockSizeKey, storeConfig.BCacheBlockSize)
+	}
+	if storeConfig.BCacheWritebackPercent < 0 || storeConfig.BCacheWritebackPercent > 100 {
+		return fmt.Errorf("'%s' value %d is out of range [0:100]", BcacheWBPercentKey, storeConfig.BCacheWritebackPercent)
+	}
+	if storeConfig.BCacheSequentialCutoff < 0 {
+		return fmt.Errorf("'%s' value %d can't be negative", BcacheSeqCutoffKey, storeConfig.BCacheSequentialCutoff)
+	}
+	if storeConfig.UseMetadataOffload && len(storeConfig.UseMetadataMask) == 0 {
+		return fmt.Errorf("'%s' requires non empty '%s'", UseMetadataOffloadKey, UseMetadataMaskKey)
+	}
+	if storeConfig.UseNVMeForMetadata && !storeConfig.UseMetadataOffload {
+		return fmt.Errorf("'%s' requires '%s' to be enabled", UseNVMeForMetadataKey, UseMetadataOffloadKey)
+	}
+	if storeConfig.RtHDDsPerSSD < 0 {
+		return fmt.Errorf("'%s' value %d can't be negative", RtHDDsPerSSDKey, storeConfig.RtHDDsPerSSD)
+	}
+	if storeConfig.RtJournalSSDCount < 0 {
+		return fmt.Errorf("'%s' value %d can't be negative", RtJournalSSDCountKey, storeConfig.RtJournalSSDCount)
+	}
+
+	return nil
+}
+
+// ParseSize converts a Kubernetes style quantity (e.g. "500Gi", "2Ti") or a plain number to bytes
+func ParseSize(raw string) (int, error) {
+	quantity, err := resource.ParseQuantity(strings.TrimSpace(raw))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse size %q: %v", raw, err)
+	}
+
+	value, ok := quantity.AsInt64()
+	if !ok {
+		// AsInt64 also fails for fractional quantities like "1.5Gi", convert those exactly
+		value, ok = decimalToInt64(quantity)
+	}
+	// ParseQuantity clamps binary SI quantities above int64 to math.MaxInt64, e.g. "8Ei"
+	if !ok || value < 0 || value == math.MaxInt64 || int64(int(value)) != value {
+		return 0, fmt.Errorf("size %q is not a valid number of bytes", raw)
+	}
+
+	return int(value), nil
+}
+
+// decimalToInt64 returns the quantity as int64, it fails if the quantity isn't a whole number or overflows
+func decimalToInt64(quantity resource.Quantity) (int64, bool) {
+	dec := quantity.AsDec()
+	value := new(big.Int).Set(dec.UnscaledBig())
+	scale := int64(dec.Scale())
+	if scale > 0 {
+		remainder := new(big.Int)
+		value.QuoRem(value, new(big.Int).Exp(big.NewInt(10), big.NewInt(scale), nil), remainder)
+		if remainder.Sign() != 0 {
+			return 0, false
+		}
+	} else if scale < 0 {
+		value.Mul(value, new(big.Int).Exp(big.NewInt(10), big.NewInt(-scale), nil))
+	}
+	if !value.IsInt64() {
+		return 0, false
+	}
+	return value.Int64(), true
+}
+
 func convertToUint64IgnoreErr(raw string) uint64 {
 	val, err := strconv.ParseUint(raw, 10, 64)
 	if err != nil {
@@ -178,3 +387,15 @@ func convertToBoolIgnoreErr(raw string) bool {
 
 	return val
 }
+
+func convertToStringSlice(raw string) []string {
+	values := make([]string, 0)
+	for _, value := range strings.Split(raw, ",") {
+		value = strings.TrimSpace(value)
+		if len(value) > 0 {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateStoreConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*StoreConfig)
+		err    string
+	}{
+		{"default", func(sc *StoreConfig) {}, ""},
+		{"hybrid", func(sc *StoreConfig) {
+			sc.UseMetadataOffload = true
+			sc.UseBCache = true
+			sc.UseBCacheWB = true
+		}, ""},
+		{"bcache writeback without bcache", func(sc *StoreConfig) {
+			sc.UseBCacheWB = true
+		}, "'useBCacheWB' requires 'useBCache' to be enabled"},
+		{"metadata offload with empty mask", func(sc *StoreConfig) {
+			sc.UseMetadataOffload = true
+			sc.UseMetadataMask = ""
+		}, "'useMetadataOffload' requires non empty 'useMetadataMask'"},
+		{"page size not power of two", func(sc *StoreConfig) {
+			sc.LmdbPageSize = 10000
+		}, "'lmdbPageSize' value 10000 is not a power of two"},
+		{"zero page size", func(sc *StoreConfig) {
+			sc.LmdbPageSize = 0
+		}, "'lmdbPageSize' value 0 is not a power of two"},
+		{"bcache block size not power of two", func(sc *StoreConfig) {
+			sc.BCacheBlockSize = 3000
+		}, "'bcacheBlockSize' value 3000 is not a power of two"},
+		{"bcache writeback percent out of range", func(sc *StoreConfig) {
+			sc.BCacheWritebackPercent = 101
+		}, "'bcacheWritebackPercent' value 101 is out of range [0:100]"},
+		{"negative bcache sequential cutoff", func(sc *StoreConfig) {
+			sc.BCacheSequentialCutoff = -1
+		}, "'bcacheSequentialCutoff' value -1 can't be negative"},
+		{"NVMe for metadata without offload", func(sc *StoreConfig) {
+			sc.UseNVMeForMetadata = true
+		}, "'useNVMeForMetadata' requires 'useMetadataOffload' to be enabled"},
+		{"verify chid out of range", func(sc *StoreConfig) {
+			sc.RtVerifyChid = 3
+		}, "'rtVerifyChid' value 3 is out of range [0:2]"},
+		{"sync out of range", func(sc *StoreConfig) {
+			sc.Sync = 4
+		}, "'sync' value 4 is out of range [0:3]"},
+		{"negative HDDs per SSD", func(sc *StoreConfig) {
+			sc.RtHDDsPerSSD = -1
+		}, "'rtHDDsPerSSD' value -1 can't be negative"},
+		{"negative journal SSD count", func(sc *StoreConfig) {
+			sc.RtJournalSSDCount = -1
+		}, "'rtJournalSSDCount' value -1 can't be negative"},
+	}
+
+	for _, test := range tests {
+		storeConfig := DefaultStoreConfig()
+		test.modify(&storeConfig)
+		err := ValidateStoreConfig(&storeConfig)
+		if test.err == "" {
+			assert.Nil(t, err, test.name)
+		} else {
+			assert.NotNil(t, err, test.name)
+			if err != nil {
+				assert.Equal(t, test.err, err.Error(), test.name)
+			}
+		}
+	}
+
+	assert.NotNil(t, ValidateStoreConfig(nil))
+}
+
+func TestValidateDeviceConfig(t *testing.T) {
+	assert.Nil(t, ValidateDeviceConfig(nil))
+	assert.Nil(t, ValidateDeviceConfig(map[string]string{SyncKey: "0", LmdbPageSizeKey: "32768", UseBcacheKey: "true"}))
+
+	err := ValidateDeviceConfig(map[string]string{SyncKey: "0", UseMetadataOffloadKey: "true"})
+	assert.NotNil(t, err)
+	assert.Equal(t, "'useMetadataOffload' can't be set in device config, it only applies to the whole node", err.Error())
+
+	for _, key := range []string{UseAllSSDKey, RtHDDsPerSSDKey, RtExcludeDevicesKey, MaxSizeKey, ZoneKey} {
+		assert.NotNil(t, ValidateDeviceConfig(map[string]string{key: "1"}), key)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	value, err := ParseSize("1Ti")
+	assert.Nil(t, err)
+	assert.Equal(t, 1099511627776, value)
+
+	value, err = ParseSize("500Gi")
+	assert.Nil(t, err)
+	assert.Equal(t, 536870912000, value)
+
+	value, err = ParseSize("16Ki")
+	assert.Nil(t, err)
+	assert.Equal(t, 16384, value)
+
+	value, err = ParseSize("32768")
+	assert.Nil(t, err)
+	assert.Equal(t, 32768, value)
+
+	value, err = ParseSize("1.5Gi")
+	assert.Nil(t, err)
+	assert.Equal(t, 1610612736, value)
+
+	for _, raw := range []string{"", "2TB", "abc", "-1Gi", "0.5", "1.5", "8Ei", "9Ei", "10E", "9223372036854775807k"} {
+		_, err = ParseSize(raw)
+		assert.NotNil(t, err, raw)
+	}
+}
+
+func TestToStoreConfigSizes(t *testing.T) {
+	storeConfig := ToStoreConfig(map[string]string{
+		MaxSizeKey:      "2Ti",
+		LmdbPageSizeKey: "32Ki",
+	})
+	assert.Equal(t, uint64(2199023255552), storeConfig.MaxSize)
+	assert.Equal(t, 32768, storeConfig.LmdbPageSize)
+
+	// invalid values are ignored
+	storeConfig = ToStoreConfig(map[string]string{
+		MaxSizeKey:      "lots",
+		LmdbPageSizeKey: "10Ki",
+	})
+	assert.Equal(t, uint64(0), storeConfig.MaxSize)
+	assert.Equal(t, 16384, storeConfig.LmdbPageSize)
+
+	// maxSize takes precedence over maxSizeGB
+	storeConfig = ToStoreConfig(map[string]string{
+		MaxSizeGB:  "100",
+		MaxSizeKey: "2Ti",
+	})
+	assert.Equal(t, uint64(2199023255552), storeConfig.MaxSize)
+
+	storeConfig = ToStoreConfig(map[string]string{
+		MaxSizeGB: "100",
+	})
+	assert.Equal(t, uint64(107374182400), storeConfig.MaxSize)
+}
@@ -19,6 +19,7 @@ package target
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	edgefsv1alpha1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1alpha1"
@@ -30,115 +31,371 @@ import (
 // CreateQualifiedHeadlessServiceName creates a qualified name of the headless service for a given replica id and namespace,
 // e.g., edgefs-0.edgefs.rook-edgefs
 func CreateQualifiedHeadlessServiceName(replicaNum int, namespace string) string {
-	return fmt.Sprintf("%s-%d.%s.%s", appName, replicaNum, appName, namespace)
+	return CreateQualifiedHeadlessServiceNameWithDomain(replicaNum, appName, appName, namespace, "")
 }
 
-func getIdDevLinkName(dls string) (dl string) {
+// CreateQualifiedHeadlessServiceNameWithDomain creates a qualified name of the headless service for a given replica id,
+// pod name prefix, service name, namespace and cluster domain, e.g., edgefs-0.edgefs.rook-edgefs.svc.cluster.local
+// for the cluster domain cluster.local. A leading "svc." of the cluster domain is dropped, so svc.cluster.local gives the
+// same name. An empty cluster domain leaves the name relative to the cluster's DNS search domains.
+func CreateQualifiedHeadlessServiceNameWithDomain(replicaNum int, name, serviceName, namespace, clusterDomain string) string {
+	qualifiedName := fmt.Sprintf("%s-%d.%s.%s", name, replicaNum, serviceName, namespace)
+	clusterDomain = strings.TrimPrefix(strings.TrimLeft(strings.TrimSpace(clusterDomain), "."), "svc.")
+	if len(clusterDomain) > 0 {
+		qualifiedName = qualifiedName + ".svc." + clusterDomain
+	}
+	return qualifiedName
+}
+
+// idDevLinkPreference is the preference order of /dev/disk/by-id link prefixes, the most durable first.
+// SCSI links are ordered by their identifier type: NAA (scsi-3) and EUI-64 (scsi-2) identifiers, then
+// vendor/model/serial (scsi-S), T10 vendor (scsi-1) and vendor specific (scsi-0) ones.
+// Links not matching any prefix are least preferred, wwn- links are never used.
+var idDevLinkPreference = []string{"scsi-3", "scsi-2", "scsi-S", "scsi-1", "scsi-0", "scsi-", "ata-", "nvme-"}
+
+func getIdDevLinkRank(dl string) int {
+	for i, prefix := range idDevLinkPreference {
+		if strings.HasPrefix(dl, prefix) {
+			return i
+		}
+	}
+	return len(idDevLinkPreference)
+}
+
+// getIdDevLinkName picks the most preferred by-id link, independently of the devlinks order
+func getIdDevLinkName(dls string) (string, error) {
+	dl := ""
+	rank := 0
 	dlsArr := strings.Split(dls, " ")
 	for i := range dlsArr {
+		if !strings.HasPrefix(dlsArr[i], "/dev/disk/by-id/") {
+			continue
+		}
 		s := strings.Replace(dlsArr[i], "/dev/disk/by-id/", "", 1)
-		if strings.Contains(s, "/") || strings.Contains(s, "wwn-") {
+		if len(s) == 0 || strings.Contains(s, "/") || strings.Contains(s, "wwn-") {
 			continue
 		}
-		dl = s
-		break
+		r := getIdDevLinkRank(s)
+		if len(dl) == 0 || r < rank || (r == rank && s < dl) {
+			dl = s
+			rank = r
+		}
+	}
+	if len(dl) == 0 {
+		return "", fmt.Errorf("no usable /dev/disk/by-id link found in %q", dls)
 	}
-	return dl
+	return dl, nil
 }
 
-func GetRTDevices(nodeDisks []sys.LocalDisk, storeConfig *config.StoreConfig) (rtDevices []edgefsv1alpha1.RTDevice, err error) {
-	rtDevices = make([]edgefsv1alpha1.RTDevice, 0)
-	if storeConfig == nil {
-		return rtDevices, fmt.Errorf("no pointer to StoreConfig provided")
+func getPathDevLinkName(dls string) (string, error) {
+	dlsArr := strings.Split(dls, " ")
+	for i := range dlsArr {
+		if !strings.HasPrefix(dlsArr[i], "/dev/disk/by-path/") {
+			continue
+		}
+		s := strings.Replace(dlsArr[i], "/dev/disk/by-path/", "", 1)
+		if len(s) == 0 || strings.Contains(s, "/") {
+			continue
+		}
+		return s, nil
 	}
+	return "", fmt.Errorf("no usable /dev/disk/by-path link found in %q", dls)
+}
 
-	if len(nodeDisks) == 0 {
-		return rtDevices, nil
+// getDevLinkName resolves the RTDevice name of a disk. The by-id link is preferred,
+// then the by-path link and finally the raw device name.
+func getDevLinkName(disk sys.LocalDisk) (string, error) {
+	if name, err := getIdDevLinkName(disk.DevLinks); err == nil {
+		return name, nil
+	}
+	if name, err := getPathDevLinkName(disk.DevLinks); err == nil {
+		logger.Warningf("device /dev/%s has no usable by-id link, using by-path name %s", disk.Name, name)
+		return name, nil
+	}
+	if len(disk.Name) > 0 {
+		logger.Warningf("device /dev/%s has no usable by-id or by-path link, using device name", disk.Name)
+		return disk.Name, nil
+	}
+	return "", fmt.Errorf("device with devlinks %q has no usable name", disk.DevLinks)
+}
+
+// getIdDevLinks returns the names of all /dev/disk/by-id links of a disk
+func getIdDevLinks(dls string) []string {
+	links := make([]string, 0)
+	for _, dl := range strings.Split(dls, " ") {
+		if strings.HasPrefix(dl, "/dev/disk/by-id/") {
+			links = append(links, strings.Replace(dl, "/dev/disk/by-id/", "", 1))
+		}
+	}
+	return links
+}
+
+// KeepDeployedRTDeviceNames renames RTDevices and their journal references back to the names already
+// deployed on the node, as long as the deployed name is still a by-id link of the same disk. This keeps
+// running devices from being renamed when the devlink preference picks another link.
+func KeepDeployedRTDeviceNames(rtDevices []edgefsv1alpha1.RTDevice, nodeDisks []sys.LocalDisk, deployed []edgefsv1alpha1.RTDevice) []edgefsv1alpha1.RTDevice {
+	deployedNames := make(map[string]bool)
+	for _, rtdev := range deployed {
+		deployedNames[rtdev.Name] = true
+		if len(rtdev.Journal) > 0 {
+			deployedNames[rtdev.Journal] = true
+		}
+	}
+	if len(deployedNames) == 0 {
+		return rtDevices
+	}
+
+	renames := make(map[string]string)
+	for _, disk := range nodeDisks {
+		name, err := getDevLinkName(disk)
+		if err != nil || deployedNames[name] {
+			continue
+		}
+		for _, link := range getIdDevLinks(disk.DevLinks) {
+			if deployedNames[link] {
+				renames[name] = link
+				break
+			}
+		}
+	}
+	if len(renames) == 0 {
+		return rtDevices
 	}
 
+	for i := range rtDevices {
+		if name, ok := renames[rtDevices[i].Name]; ok {
+			logger.Infof("keeping deployed name %s for device %s instead of %s", name, rtDevices[i].Device, rtDevices[i].Name)
+			rtDevices[i].Name = name
+		}
+		if journal, ok := renames[rtDevices[i].Journal]; ok {
+			if strings.HasPrefix(rtDevices[i].Metadata, rtDevices[i].Journal+",") {
+				rtDevices[i].Metadata = journal + strings.TrimPrefix(rtDevices[i].Metadata, rtDevices[i].Journal)
+			}
+			rtDevices[i].Journal = journal
+		}
+	}
+	sortRTDevices(rtDevices)
+	return rtDevices
+}
+
+// getDiskSortKey returns a stable key used to order disks independently of the enumeration order
+func getDiskSortKey(disk sys.LocalDisk) string {
+	if name, err := getIdDevLinkName(disk.DevLinks); err == nil {
+		return name
+	}
+	return "/dev/" + disk.Name
+}
+
+func sortDisks(disks []sys.LocalDisk) {
+	sort.SliceStable(disks, func(i, j int) bool {
+		return getDiskSortKey(disks[i]) < getDiskSortKey(disks[j])
+	})
+}
+
+func sortRTDevices(rtDevices []edgefsv1alpha1.RTDevice) {
+	sort.SliceStable(rtDevices, func(i, j int) bool {
+		if rtDevices[i].Name != rtDevices[j].Name {
+			return rtDevices[i].Name < rtDevices[j].Name
+		}
+		return rtDevices[i].Device < rtDevices[j].Device
+	})
+}
+
+// isDeviceListed checks whether a disk matches any entry of the list by name, /dev path or devlink
+func isDeviceListed(disk sys.LocalDisk, list []string) bool {
+	for _, entry := range list {
+		if entry == disk.Name || entry == "/dev/"+disk.Name {
+			return true
+		}
+		for _, dl := range strings.Split(disk.DevLinks, " ") {
+			if len(dl) > 0 && (entry == dl || entry == filepath.Base(dl)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isNVMeDevice(disk sys.LocalDisk) bool {
+	return strings.HasPrefix(disk.Name, "nvme") || strings.Contains(disk.DevLinks, "nvme")
+}
+
+func makeRTDevice(disk sys.LocalDisk, storeConfig *config.StoreConfig) (edgefsv1alpha1.RTDevice, error) {
+	name, err := getDevLinkName(disk)
+	if err != nil {
+		return edgefsv1alpha1.RTDevice{}, err
+	}
+	rtdev := edgefsv1alpha1.RTDevice{
+		Name:       name,
+		Device:     "/dev/" + disk.Name,
+		Psize:      storeConfig.LmdbPageSize,
+		VerifyChid: storeConfig.RtVerifyChid,
+		Sync:       storeConfig.Sync,
+	}
+	if storeConfig.RtPLevelOverride != 0 {
+		rtdev.PlevelOverride = storeConfig.RtPLevelOverride
+	}
+	return rtdev, nil
+}
+
+const (
+	layoutModeAllSSD = "all-SSD"
+	layoutModeAllHDD = "all-HDD"
+	layoutModeHybrid = "hybrid"
+)
+
+type skippedDisk struct {
+	disk   sys.LocalDisk
+	reason string
+}
+
+type journalGroup struct {
+	journal sys.LocalDisk
+	hdds    []sys.LocalDisk
+}
+
+// layoutPlan is the result of the disk selection logic shared by GetRTDevices and DescribeLayout
+type layoutPlan struct {
+	mode     string
+	groups   []journalGroup
+	data     []sys.LocalDisk
+	skipped  []skippedDisk
+	warnings []string
+}
+
+func planLayout(nodeDisks []sys.LocalDisk, storeConfig *config.StoreConfig) (*layoutPlan, error) {
+	plan, err := selectLayoutDisks(nodeDisks, storeConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDuplicateDevLinkNames(plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func selectLayoutDisks(nodeDisks []sys.LocalDisk, storeConfig *config.StoreConfig) (*layoutPlan, error) {
+	plan := &layoutPlan{}
+
+	var nvmes []sys.LocalDisk
 	var ssds []sys.LocalDisk
 	var hdds []sys.LocalDisk
-	var devices []sys.LocalDisk
 
 	for i := range nodeDisks {
-		if !nodeDisks[i].Empty || len(nodeDisks[i].Partitions) > 0 {
+		if isDeviceListed(nodeDisks[i], storeConfig.RtExcludeDevices) {
+			plan.skipped = append(plan.skipped, skippedDisk{nodeDisks[i], "excluded by rtExcludeDevices"})
+			continue
+		}
+		if len(storeConfig.RtIncludeDevices) > 0 {
+			if !isDeviceListed(nodeDisks[i], storeConfig.RtIncludeDevices) {
+				plan.skipped = append(plan.skipped, skippedDisk{nodeDisks[i], "not included by rtIncludeDevices"})
+				continue
+			}
+		} else if !nodeDisks[i].Empty || len(nodeDisks[i].Partitions) > 0 {
+			plan.skipped = append(plan.skipped, skippedDisk{nodeDisks[i], "not empty or has partitions"})
 			continue
 		}
 		if nodeDisks[i].Rotational {
 			hdds = append(hdds, nodeDisks[i])
+		} else if isNVMeDevice(nodeDisks[i]) {
+			nvmes = append(nvmes, nodeDisks[i])
 		} else {
 			ssds = append(ssds, nodeDisks[i])
 		}
-		devices = append(devices, nodeDisks[i])
 	}
 
-	//var rtdevs []RTDevice
+	// Deterministic ordering, so identical hardware always yields identical layout
+	sortDisks(nvmes)
+	sortDisks(ssds)
+	sortDisks(hdds)
+
 	if storeConfig.UseAllSSD {
 		//
 		// All flush media case (High Performance)
 		//
-		if len(ssds) == 0 {
-			return rtDevices, fmt.Errorf("No SSD/NVMe media found")
+		if len(ssds) == 0 && len(nvmes) == 0 {
+			return nil, fmt.Errorf("No SSD/NVMe media found")
 		}
+		plan.mode = layoutModeAllSSD
 		if storeConfig.UseMetadataOffload {
-			fmt.Println("Warning: useMetadataOffload parameter is ignored due to use useAllSSD=true")
+			plan.warnings = append(plan.warnings, "useMetadataOffload parameter is ignored due to use useAllSSD=true")
 		}
-
-		for i := range devices {
-			if devices[i].Rotational {
-				continue
-			}
-			rtdev := edgefsv1alpha1.RTDevice{
-				Name:       getIdDevLinkName(devices[i].DevLinks),
-				Device:     "/dev/" + devices[i].Name,
-				Psize:      storeConfig.LmdbPageSize,
-				VerifyChid: storeConfig.RtVerifyChid,
-				Sync:       storeConfig.Sync,
-			}
-			if storeConfig.RtPLevelOverride != 0 {
-				rtdev.PlevelOverride = storeConfig.RtPLevelOverride
-			}
-			rtDevices = append(rtDevices, rtdev)
+		plan.data = append(plan.data, ssds...)
+		plan.data = append(plan.data, nvmes...)
+		sortDisks(plan.data)
+		for i := range hdds {
+			plan.skipped = append(plan.skipped, skippedDisk{hdds[i], "rotational device with useAllSSD=true"})
 		}
-		return rtDevices, nil
+		sortSkippedDisks(plan.skipped)
+		return plan, nil
 	}
 
 	if len(hdds) == 0 {
-		return rtDevices, fmt.Errorf("No HDD media found")
+		return nil, fmt.Errorf("No HDD media found")
 	}
 
 	if !storeConfig.UseMetadataOffload {
 		//
 		// All HDD media case (capacity, cold archive)
 		//
-		for i := range devices {
-			if !devices[i].Rotational {
-				continue
-			}
-			rtdev := edgefsv1alpha1.RTDevice{
-				Name:       getIdDevLinkName(devices[i].DevLinks),
-				Device:     "/dev/" + devices[i].Name,
-				Psize:      storeConfig.LmdbPageSize,
-				VerifyChid: storeConfig.RtVerifyChid,
-				Sync:       storeConfig.Sync,
+		// SSDs are used as plain data devices too, unless reserved
+		plan.mode = layoutModeAllHDD
+		plan.data = append(plan.data, hdds...)
+		flash := append(append([]sys.LocalDisk{}, ssds...), nvmes...)
+		if storeConfig.RtReserveSSDs {
+			sortDisks(flash)
+			for i := range flash {
+				plan.skipped = append(plan.skipped, skippedDisk{flash[i], "non-rotational device reserved with rtReserveSSDs=true"})
+				plan.warnings = append(plan.warnings, fmt.Sprintf("SSD /dev/%s skipped, reserved with rtReserveSSDs=true", flash[i].Name))
 			}
-			if storeConfig.RtPLevelOverride != 0 {
-				rtdev.PlevelOverride = storeConfig.RtPLevelOverride
-			}
-			rtDevices = append(rtDevices, rtdev)
+		} else {
+			plan.data = append(plan.data, flash...)
 		}
-		return rtDevices, nil
+		sortDisks(plan.data)
+		sortSkippedDisks(plan.skipped)
+		return plan, nil
 	}
 
 	//
 	// Hybrid SSD/HDD media case (optimal)
 	//
-	if len(hdds) < len(ssds) || len(ssds) == 0 {
-		return rtDevices, fmt.Errorf("Confusing use of useMetadataOffload parameter HDDs(%d) < SSDs(%d)\n", len(hdds), len(ssds))
+	// By default all flash devices are used as journals. With useNVMeForMetadata
+	// only NVMe devices back the HDDs and SATA SSDs become data devices.
+	plan.mode = layoutModeHybrid
+	journals := make([]sys.LocalDisk, 0, len(ssds)+len(nvmes))
+	journals = append(journals, ssds...)
+	journals = append(journals, nvmes...)
+	if storeConfig.UseNVMeForMetadata {
+		if len(nvmes) == 0 {
+			return nil, fmt.Errorf("No NVMe media found for metadata offload, useNVMeForMetadata=true")
+		}
+		journals = nvmes
+		plan.data = append(plan.data, ssds...)
+	}
+
+	// Only first rtJournalSSDCount SSDs are used as journals, the rest become data devices
+	if storeConfig.RtJournalSSDCount != 0 {
+		if storeConfig.RtJournalSSDCount < 1 || storeConfig.RtJournalSSDCount > len(journals) {
+			return nil, fmt.Errorf("rtJournalSSDCount=%d is out of range [1:%d] of available SSDs",
+				storeConfig.RtJournalSSDCount, len(journals))
+		}
+		plan.data = append(plan.data, journals[storeConfig.RtJournalSSDCount:]...)
+		sortDisks(plan.data)
+		journals = journals[:storeConfig.RtJournalSSDCount]
 	}
 
-	var hdds_divided [][]sys.LocalDisk
-	for i := len(ssds); i > 0; i-- {
+	journalCount := len(journals)
+	if storeConfig.RtHDDsPerSSD > 0 {
+		journalCount = (len(hdds) + storeConfig.RtHDDsPerSSD - 1) / storeConfig.RtHDDsPerSSD
+		if journalCount > len(journals) {
+			return nil, fmt.Errorf("rtHDDsPerSSD=%d with SSDs(%d) can't cover HDDs(%d), at least %d SSDs needed",
+				storeConfig.RtHDDsPerSSD, len(journals), len(hdds), journalCount)
+		}
+	} else if len(hdds) < len(journals) || len(journals) == 0 {
+		return nil, fmt.Errorf("Confusing use of useMetadataOffload parameter HDDs(%d) < SSDs(%d)\n", len(hdds), len(journals))
+	}
+
+	for i := journalCount; i > 0; i-- {
 		chunkSize := len(hdds) / i
 		mod := len(hdds) % i
 		if mod > 0 {
@@ -148,45 +405,194 @@ func GetRTDevices(nodeDisks []sys.LocalDisk, storeConfig *config.StoreConfig) (r
 		if len(hdds) < chunkSize {
 			chunkSize = len(hdds)
 		}
-		hdds_divided = append(hdds_divided, hdds[:chunkSize])
+		plan.groups = append(plan.groups, journalGroup{journals[journalCount-i], hdds[:chunkSize]})
 		hdds = hdds[chunkSize:]
 	}
 
-	for i := range hdds_divided {
-		for j := range hdds_divided[i] {
-			rtdev := edgefsv1alpha1.RTDevice{
-				Name:              getIdDevLinkName(hdds_divided[i][j].DevLinks),
-				Device:            "/dev/" + hdds_divided[i][j].Name,
-				Psize:             storeConfig.LmdbPageSize,
-				VerifyChid:        storeConfig.RtVerifyChid,
-				BcacheWritearound: (map[bool]int{true: 0, false: 1})[storeConfig.UseBCacheWB],
-				Journal:           getIdDevLinkName(ssds[i].DevLinks),
-				Metadata:          getIdDevLinkName(ssds[i].DevLinks) + "," + storeConfig.UseMetadataMask,
-				Bcache:            0,
-				Sync:              storeConfig.Sync,
+	for i := journalCount; i < len(journals); i++ {
+		plan.skipped = append(plan.skipped, skippedDisk{journals[i], fmt.Sprintf("not needed with rtHDDsPerSSD=%d", storeConfig.RtHDDsPerSSD)})
+	}
+	sortSkippedDisks(plan.skipped)
+	return plan, nil
+}
+
+func sortSkippedDisks(skipped []skippedDisk) {
+	sort.SliceStable(skipped, func(i, j int) bool {
+		return getDiskSortKey(skipped[i].disk) < getDiskSortKey(skipped[j].disk)
+	})
+}
+
+// getDeviceStoreConfig returns the base StoreConfig merged with the per-device settings matched by device name
+// or devlink, or the base one if there are none
+func getDeviceStoreConfig(disk sys.LocalDisk, storeConfig *config.StoreConfig, deviceConfigs map[string]map[string]string) *config.StoreConfig {
+	keys := make([]string, 0, len(deviceConfigs))
+	for key := range deviceConfigs {
+		keys = append(keys, key)
+	}
+	// deterministic match in case of several keys referring to the same device
+	sort.Strings(keys)
+	for _, key := range keys {
+		if isDeviceListed(disk, []string{key}) {
+			deviceConfig := config.ApplyStoreConfig(*storeConfig, deviceConfigs[key])
+			return &deviceConfig
+		}
+	}
+	return storeConfig
+}
+
+// GetRTDevices translates node disks to RTDevices. The deviceConfigs are per-device config settings
+// keyed by device name or devlink, applied on top of the base storeConfig when building the matched
+// device, so that settings not present there are inherited. Advisory messages about the chosen layout
+// are returned as warnings, so the caller can log or report them in the node's context.
+func GetRTDevices(nodeDisks []sys.LocalDisk, storeConfig *config.StoreConfig, deviceConfigs map[string]map[string]string) (rtDevices []edgefsv1alpha1.RTDevice, warnings []string, err error) {
+	rtDevices = make([]edgefsv1alpha1.RTDevice, 0)
+	if storeConfig == nil {
+		return rtDevices, nil, fmt.Errorf("no pointer to StoreConfig provided")
+	}
+
+	if len(nodeDisks) == 0 {
+		return rtDevices, nil, nil
+	}
+
+	plan, err := planLayout(nodeDisks, storeConfig)
+	if err != nil {
+		return rtDevices, nil, err
+	}
+
+	for i := range plan.groups {
+		journalName, err := getDevLinkName(plan.groups[i].journal)
+		if err != nil {
+			return make([]edgefsv1alpha1.RTDevice, 0), nil, err
+		}
+		for j := range plan.groups[i].hdds {
+			devConfig := getDeviceStoreConfig(plan.groups[i].hdds[j], storeConfig, deviceConfigs)
+			rtdev, err := makeRTDevice(plan.groups[i].hdds[j], devConfig)
+			if err != nil {
+				return make([]edgefsv1alpha1.RTDevice, 0), nil, err
 			}
+			rtdev.BcacheWritearound = (map[bool]int{true: 0, false: 1})[devConfig.UseBCacheWB]
+			rtdev.Journal = journalName
+			rtdev.Metadata = journalName + "," + devConfig.UseMetadataMask
+			rtdev.Bcache = 0
 
-			if storeConfig.UseBCache {
+			if devConfig.UseBCache {
 				rtdev.Bcache = 1
-				if storeConfig.UseBCacheWB {
+				rtdev.BcacheBlockSize = devConfig.BCacheBlockSize
+				rtdev.BcacheSequentialCutoff = devConfig.BCacheSequentialCutoff
+				if devConfig.UseBCacheWB {
 					rtdev.BcacheWritearound = 0
+					rtdev.BcacheWritebackPercent = devConfig.BCacheWritebackPercent
 				}
 			}
-
-			if storeConfig.RtPLevelOverride != 0 {
-				rtdev.PlevelOverride = storeConfig.RtPLevelOverride
-			}
 			rtDevices = append(rtDevices, rtdev)
 		}
 	}
-	return rtDevices, nil
+
+	for i := range plan.data {
+		rtdev, err := makeRTDevice(plan.data[i], getDeviceStoreConfig(plan.data[i], storeConfig, deviceConfigs))
+		if err != nil {
+			return make([]edgefsv1alpha1.RTDevice, 0), nil, err
+		}
+		rtDevices = append(rtDevices, rtdev)
+	}
+	sortRTDevices(rtDevices)
+	return rtDevices, plan.warnings, nil
+}
+
+// checkDuplicateDevLinkNames verifies each planned RTDevice and journal gets a unique name, e.g. multipath
+// devices may expose the same by-id link on several /dev nodes
+func checkDuplicateDevLinkNames(plan *layoutPlan) error {
+	var disks []sys.LocalDisk
+	for _, group := range plan.groups {
+		disks = append(disks, group.journal)
+		disks = append(disks, group.hdds...)
+	}
+	disks = append(disks, plan.data...)
+
+	rtDevices := make([]edgefsv1alpha1.RTDevice, 0, len(disks))
+	for _, disk := range disks {
+		name, err := getDevLinkName(disk)
+		if err != nil {
+			return err
+		}
+		rtDevices = append(rtDevices, edgefsv1alpha1.RTDevice{Name: name, Device: "/dev/" + disk.Name})
+	}
+	sortRTDevices(rtDevices)
+
+	devicesByName := make(map[string][]string)
+	names := make([]string, 0)
+	for _, rtdev := range rtDevices {
+		if _, ok := devicesByName[rtdev.Name]; !ok {
+			names = append(names, rtdev.Name)
+		}
+		devicesByName[rtdev.Name] = append(devicesByName[rtdev.Name], rtdev.Device)
+	}
+
+	conflicts := make([]string, 0)
+	for _, name := range names {
+		if len(devicesByName[name]) > 1 {
+			conflicts = append(conflicts, fmt.Sprintf("%s (%s)", name, strings.Join(devicesByName[name], ", ")))
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("duplicate RTDevice names found: %s", strings.Join(conflicts, "; "))
+	}
+	return nil
+}
+
+func describeDisk(disk sys.LocalDisk) string {
+	name, err := getDevLinkName(disk)
+	if err != nil {
+		return "/dev/" + disk.Name
+	}
+	return fmt.Sprintf("/dev/%s (%s)", disk.Name, name)
+}
+
+// DescribeLayout returns a human readable summary of the RTDevices layout GetRTDevices would build
+func DescribeLayout(nodeDisks []sys.LocalDisk, storeConfig *config.StoreConfig) (string, error) {
+	if storeConfig == nil {
+		return "", fmt.Errorf("no pointer to StoreConfig provided")
+	}
+
+	if len(nodeDisks) == 0 {
+		return "no disks found\n", nil
+	}
+
+	plan, err := planLayout(nodeDisks, storeConfig)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "mode: %s\n", plan.mode)
+	for _, group := range plan.groups {
+		hdds := make([]string, 0, len(group.hdds))
+		for _, hdd := range group.hdds {
+			hdds = append(hdds, describeDisk(hdd))
+		}
+		fmt.Fprintf(&b, "journal %s backs %d HDD(s): %s\n", describeDisk(group.journal), len(group.hdds), strings.Join(hdds, ", "))
+	}
+	for _, disk := range plan.data {
+		fmt.Fprintf(&b, "data %s\n", describeDisk(disk))
+	}
+	for _, skipped := range plan.skipped {
+		fmt.Fprintf(&b, "skipped %s: %s\n", describeDisk(skipped.disk), skipped.reason)
+	}
+	for _, warning := range plan.warnings {
+		fmt.Fprintf(&b, "warning: %s\n", warning)
+	}
+	return b.String(), nil
 }
 
-func GetRtlfsDevices(directories []rookalpha.Directory, storeConfig *config.StoreConfig) []edgefsv1alpha1.RtlfsDevice {
+func GetRtlfsDevices(directories []rookalpha.Directory, storeConfig *config.StoreConfig) ([]edgefsv1alpha1.RtlfsDevice, error) {
 	rtlfsDevices := make([]edgefsv1alpha1.RtlfsDevice, 0)
 	for _, dir := range directories {
+		name := filepath.Base(dir.Path)
+		if len(dir.Path) == 0 || name == "." || name == "/" {
+			return rtlfsDevices, fmt.Errorf("directory %q has no usable rtlfs device name", dir.Path)
+		}
 		rtlfsDevice := edgefsv1alpha1.RtlfsDevice{
-			Name:            filepath.Base(dir.Path),
+			Name:            name,
 			Path:            dir.Path,
 			CheckMountpoint: 0,
 			Psize:           storeConfig.LmdbPageSize,
@@ -201,5 +607,5 @@ func GetRtlfsDevices(directories []rookalpha.Directory, storeConfig *config.Stor
 		}
 		rtlfsDevices = append(rtlfsDevices, rtlfsDevice)
 	}
-	return rtlfsDevices
+	return rtlfsDevices, nil
 }
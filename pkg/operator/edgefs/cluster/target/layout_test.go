@@ -0,0 +1,712 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package target
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	edgefsv1alpha1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1alpha1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/operator/edgefs/cluster/target/config"
+	"github.com/rook/rook/pkg/util/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+func testHDD(name string) sys.LocalDisk {
+	return sys.LocalDisk{
+		Name:       name,
+		DevLinks:   "/dev/disk/by-id/ata-HDD_" + name + " /dev/disk/by-path/pci-0000:00:1f.2-ata-" + name,
+		Rotational: true,
+		Empty:      true,
+	}
+}
+
+func testSSD(name string) sys.LocalDisk {
+	return sys.LocalDisk{
+		Name:       name,
+		DevLinks:   "/dev/disk/by-id/ata-SSD_" + name + " /dev/disk/by-path/pci-0000:00:1f.5-ata-" + name,
+		Rotational: false,
+		Empty:      true,
+	}
+}
+
+func testNVMe(name string) sys.LocalDisk {
+	return sys.LocalDisk{
+		Name:       name,
+		DevLinks:   "/dev/disk/by-id/nvme-NVME_" + name + " /dev/disk/by-path/pci-0000:03:00.0-nvme-" + name,
+		Rotational: false,
+		Empty:      true,
+	}
+}
+
+func TestGetRTDevicesNVMeForMetadata(t *testing.T) {
+	disks := []sys.LocalDisk{testHDD("sda"), testHDD("sdb"), testSSD("sdc"), testNVMe("nvme0n1")}
+	storeConfig := config.DefaultStoreConfig()
+	storeConfig.UseMetadataOffload = true
+	storeConfig.UseNVMeForMetadata = true
+
+	rtDevices, _, err := GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(rtDevices))
+
+	// both HDDs are backed by the NVMe device
+	assert.Equal(t, "/dev/sda", rtDevices[0].Device)
+	assert.Equal(t, "nvme-NVME_nvme0n1", rtDevices[0].Journal)
+	assert.Equal(t, "nvme-NVME_nvme0n1,0xff", rtDevices[0].Metadata)
+	assert.Equal(t, "/dev/sdb", rtDevices[1].Device)
+	assert.Equal(t, "nvme-NVME_nvme0n1", rtDevices[1].Journal)
+
+	// SATA SSD becomes a data device
+	assert.Equal(t, "/dev/sdc", rtDevices[2].Device)
+	assert.Equal(t, "ata-SSD_sdc", rtDevices[2].Name)
+	assert.Equal(t, "", rtDevices[2].Journal)
+	assert.Equal(t, "", rtDevices[2].Metadata)
+}
+
+func TestGetRTDevicesNVMeForMetadataNoNVMe(t *testing.T) {
+	disks := []sys.LocalDisk{testHDD("sda"), testHDD("sdb"), testSSD("sdc")}
+	storeConfig := config.DefaultStoreConfig()
+	storeConfig.UseMetadataOffload = true
+	storeConfig.UseNVMeForMetadata = true
+
+	rtDevices, _, err := GetRTDevices(disks, &storeConfig, nil)
+	assert.NotNil(t, err)
+	assert.NotEqual(t, "No SSD/NVMe media found", err.Error())
+	assert.Contains(t, err.Error(), "No NVMe media found")
+	assert.Equal(t, 0, len(rtDevices))
+}
+
+func TestGetRTDevicesHybridWithoutNVMeFlag(t *testing.T) {
+	disks := []sys.LocalDisk{testHDD("sda"), testHDD("sdb"), testSSD("sdc"), testNVMe("nvme0n1")}
+	storeConfig := config.DefaultStoreConfig()
+	storeConfig.UseMetadataOffload = true
+
+	rtDevices, _, err := GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rtDevices))
+	assert.Equal(t, "ata-SSD_sdc", rtDevices[0].Journal)
+	assert.Equal(t, "nvme-NVME_nvme0n1", rtDevices[1].Journal)
+}
+
+func TestGetRTDevicesAllSSD(t *testing.T) {
+	disks := []sys.LocalDisk{testHDD("sda"), testSSD("sdb"), testSSD("sdc")}
+	storeConfig := config.DefaultStoreConfig()
+	storeConfig.UseAllSSD = true
+
+	rtDevices, _, err := GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rtDevices))
+	assert.Equal(t, "/dev/sdb", rtDevices[0].Device)
+	assert.Equal(t, "/dev/sdc", rtDevices[1].Device)
+
+	_, _, err = GetRTDevices([]sys.LocalDisk{testHDD("sda")}, &storeConfig, nil)
+	assert.Equal(t, "No SSD/NVMe media found", err.Error())
+}
+
+func TestGetIdDevLinkNameOnlyWWN(t *testing.T) {
+	dls := "/dev/disk/by-id/wwn-0x5000c500a1b2c3d4 /dev/disk/by-id/wwn-0x5000c500a1b2c3d5"
+	name, err := getIdDevLinkName(dls)
+	assert.NotNil(t, err)
+	assert.Equal(t, "", name)
+
+	name, err = getIdDevLinkName("/dev/disk/by-id/wwn-0x5000c500a1b2c3d4 /dev/disk/by-id/ata-HDD_1")
+	assert.Nil(t, err)
+	assert.Equal(t, "ata-HDD_1", name)
+}
+
+func TestGetRTDevicesOnlyWWNLinks(t *testing.T) {
+	storeConfig := config.DefaultStoreConfig()
+
+	// falls back to by-path link
+	disks := []sys.LocalDisk{
+		{
+			Name:       "sda",
+			DevLinks:   "/dev/disk/by-id/wwn-0x5000c500a1b2c3d4 /dev/disk/by-path/pci-0000:00:1f.2-ata-1",
+			Rotational: true,
+			Empty:      true,
+		},
+		{
+			Name:       "sdb",
+			DevLinks:   "/dev/disk/by-id/wwn-0x5000c500a1b2c3d5",
+			Rotational: true,
+			Empty:      true,
+		},
+	}
+	rtDevices, _, err := GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rtDevices))
+	assert.Equal(t, "pci-0000:00:1f.2-ata-1", rtDevices[0].Name)
+	// falls back to raw device name
+	assert.Equal(t, "sdb", rtDevices[1].Name)
+
+	// no usable name at all
+	disks = []sys.LocalDisk{
+		{
+			DevLinks:   "/dev/disk/by-id/wwn-0x5000c500a1b2c3d4",
+			Rotational: true,
+			Empty:      true,
+		},
+	}
+	rtDevices, _, err = GetRTDevices(disks, &storeConfig, nil)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "wwn-0x5000c500a1b2c3d4")
+	assert.Equal(t, 0, len(rtDevices))
+}
+
+func TestGetRtlfsDevicesInvalidPath(t *testing.T) {
+	storeConfig := config.DefaultStoreConfig()
+	dirs := []rookalpha.Directory{{Path: "/mnt/disks/ssd0"}, {Path: "/"}}
+	rtlfsDevices, err := GetRtlfsDevices(dirs, &storeConfig)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "\"/\"")
+	assert.Equal(t, 1, len(rtlfsDevices))
+	assert.Equal(t, "ssd0", rtlfsDevices[0].Name)
+}
+
+func TestGetRTDevicesDeterministicOrder(t *testing.T) {
+	storeConfig := config.DefaultStoreConfig()
+	storeConfig.UseMetadataOffload = true
+
+	disks := []sys.LocalDisk{testHDD("sda"), testHDD("sdb"), testHDD("sdc"), testSSD("sdd"), testSSD("sde")}
+	shuffled := []sys.LocalDisk{testSSD("sde"), testHDD("sdc"), testHDD("sda"), testSSD("sdd"), testHDD("sdb")}
+
+	rtDevices, _, err := GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	rtDevicesShuffled, _, err := GetRTDevices(shuffled, &storeConfig, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(rtDevices))
+	assert.Equal(t, rtDevices, rtDevicesShuffled)
+	assert.Equal(t, "ata-SSD_sdd", rtDevices[0].Journal)
+	assert.Equal(t, "ata-SSD_sdd", rtDevices[1].Journal)
+	assert.Equal(t, "ata-SSD_sde", rtDevices[2].Journal)
+
+	storeConfig.UseMetadataOffload = false
+	rtDevices, _, err = GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	rtDevicesShuffled, _, err = GetRTDevices(shuffled, &storeConfig, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, rtDevices, rtDevicesShuffled)
+}
+
+func TestGetRTDevicesHDDsPerSSD(t *testing.T) {
+	storeConfig := config.DefaultStoreConfig()
+	storeConfig.UseMetadataOffload = true
+
+	// zero (default), HDDs evenly divided across all SSDs
+	disks := []sys.LocalDisk{testHDD("sda"), testHDD("sdb"), testHDD("sdc"), testHDD("sdd"), testSSD("sde"), testSSD("sdf")}
+	rtDevices, _, err := GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(rtDevices))
+	assert.Equal(t, "ata-SSD_sde", rtDevices[0].Journal)
+	assert.Equal(t, "ata-SSD_sde", rtDevices[1].Journal)
+	assert.Equal(t, "ata-SSD_sdf", rtDevices[2].Journal)
+	assert.Equal(t, "ata-SSD_sdf", rtDevices[3].Journal)
+
+	// exact fit
+	storeConfig.RtHDDsPerSSD = 2
+	rtDevices, _, err = GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(rtDevices))
+	assert.Equal(t, "ata-SSD_sde", rtDevices[1].Journal)
+	assert.Equal(t, "ata-SSD_sdf", rtDevices[2].Journal)
+
+	// single SSD backs all HDDs, the other one is left out
+	storeConfig.RtHDDsPerSSD = 4
+	rtDevices, _, err = GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(rtDevices))
+	for i := range rtDevices {
+		assert.Equal(t, "ata-SSD_sde", rtDevices[i].Journal)
+	}
+
+	// under-provisioned
+	storeConfig.RtHDDsPerSSD = 1
+	rtDevices, _, err = GetRTDevices(disks, &storeConfig, nil)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "rtHDDsPerSSD=1")
+	assert.Equal(t, 0, len(rtDevices))
+}
+
+func TestGetRTDevicesIncludeExclude(t *testing.T) {
+	partitioned := testHDD("sda")
+	partitioned.Empty = false
+	partitioned.Partitions = []sys.Partition{{Name: "sda1"}}
+	disks := []sys.LocalDisk{partitioned, testHDD("sdb"), testHDD("sdc")}
+
+	storeConfig := config.DefaultStoreConfig()
+	rtDevices, _, err := GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rtDevices))
+
+	// excluded empty disk is dropped
+	storeConfig.RtExcludeDevices = []string{"/dev/disk/by-id/ata-HDD_sdc"}
+	rtDevices, _, err = GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(rtDevices))
+	assert.Equal(t, "/dev/sdb", rtDevices[0].Device)
+
+	// by-path link matches only its own disk
+	storeConfig.RtExcludeDevices = []string{"/dev/disk/by-path/pci-0000:00:1f.2-ata-sdb"}
+	rtDevices, _, err = GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(rtDevices))
+	assert.Equal(t, "/dev/sdc", rtDevices[0].Device)
+
+	// included partitioned disk is considered, exclude wins on conflict
+	storeConfig.RtIncludeDevices = []string{"sda", "sdb", "sdc"}
+	storeConfig.RtExcludeDevices = []string{"sdc"}
+	rtDevices, _, err = GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rtDevices))
+	assert.Equal(t, "/dev/sda", rtDevices[0].Device)
+	assert.Equal(t, "/dev/sdb", rtDevices[1].Device)
+}
+
+func TestDescribeLayout(t *testing.T) {
+	partitioned := testHDD("sdx")
+	partitioned.Empty = false
+	disks := []sys.LocalDisk{testHDD("sda"), testHDD("sdb"), testHDD("sdc"), testSSD("sdd"), testSSD("sde"), partitioned}
+
+	// all-SSD
+	storeConfig := config.DefaultStoreConfig()
+	storeConfig.UseAllSSD = true
+	summary, err := DescribeLayout(disks, &storeConfig)
+	assert.Nil(t, err)
+	assert.Equal(t, "mode: all-SSD\n"+
+		"data /dev/sdd (ata-SSD_sdd)\n"+
+		"data /dev/sde (ata-SSD_sde)\n"+
+		"skipped /dev/sda (ata-HDD_sda): rotational device with useAllSSD=true\n"+
+		"skipped /dev/sdb (ata-HDD_sdb): rotational device with useAllSSD=true\n"+
+		"skipped /dev/sdc (ata-HDD_sdc): rotational device with useAllSSD=true\n"+
+		"skipped /dev/sdx (ata-HDD_sdx): not empty or has partitions\n", summary)
+
+	// all-HDD
+	storeConfig = config.DefaultStoreConfig()
+	summary, err = DescribeLayout(disks, &storeConfig)
+	assert.Nil(t, err)
+	assert.Equal(t, "mode: all-HDD\n"+
+		"data /dev/sda (ata-HDD_sda)\n"+
+		"data /dev/sdb (ata-HDD_sdb)\n"+
+		"data /dev/sdc (ata-HDD_sdc)\n"+
+		"data /dev/sdd (ata-SSD_sdd)\n"+
+		"data /dev/sde (ata-SSD_sde)\n"+
+		"skipped /dev/sdx (ata-HDD_sdx): not empty or has partitions\n", summary)
+
+	// hybrid
+	storeConfig.UseMetadataOffload = true
+	summary, err = DescribeLayout(disks, &storeConfig)
+	assert.Nil(t, err)
+	assert.Equal(t, "mode: hybrid\n"+
+		"journal /dev/sdd (ata-SSD_sdd) backs 2 HDD(s): /dev/sda (ata-HDD_sda), /dev/sdb (ata-HDD_sdb)\n"+
+		"journal /dev/sde (ata-SSD_sde) backs 1 HDD(s): /dev/sdc (ata-HDD_sdc)\n"+
+		"skipped /dev/sdx (ata-HDD_sdx): not empty or has partitions\n", summary)
+
+	// plan never diverges from the built layout
+	rtDevices, _, err := GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(rtDevices))
+	assert.Equal(t, "ata-SSD_sdd", rtDevices[1].Journal)
+	assert.Equal(t, "ata-SSD_sde", rtDevices[2].Journal)
+
+	_, err = DescribeLayout(disks, nil)
+	assert.NotNil(t, err)
+}
+
+func TestGetRTDevicesWarnings(t *testing.T) {
+	disks := []sys.LocalDisk{testSSD("sda"), testSSD("sdb")}
+	storeConfig := config.DefaultStoreConfig()
+	storeConfig.UseAllSSD = true
+
+	rtDevices, warnings, err := GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rtDevices))
+	assert.Equal(t, 0, len(warnings))
+
+	storeConfig.UseMetadataOffload = true
+	rtDevices, warnings, err = GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rtDevices))
+	assert.Equal(t, []string{"useMetadataOffload parameter is ignored due to use useAllSSD=true"}, warnings)
+}
+
+func TestGetRTDevicesDeviceConfigs(t *testing.T) {
+	disks := []sys.LocalDisk{testHDD("sda"), testHDD("sdb"), testHDD("sdc")}
+	storeConfig := config.DefaultStoreConfig()
+	deviceConfigs := map[string]map[string]string{
+		"sdb": {"sync": "0", "lmdbPageSize": "32768"},
+	}
+
+	rtDevices, _, err := GetRTDevices(disks, &storeConfig, deviceConfigs)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(rtDevices))
+	for _, rtdev := range rtDevices {
+		if rtdev.Device == "/dev/sdb" {
+			assert.Equal(t, 0, rtdev.Sync)
+			assert.Equal(t, 32768, rtdev.Psize)
+		} else {
+			assert.Equal(t, storeConfig.Sync, rtdev.Sync)
+			assert.Equal(t, storeConfig.LmdbPageSize, rtdev.Psize)
+		}
+		// not overridden, inherited from base
+		assert.Equal(t, storeConfig.RtVerifyChid, rtdev.VerifyChid)
+	}
+
+	// match by devlink
+	deviceConfigs = map[string]map[string]string{
+		"/dev/disk/by-id/ata-HDD_sdc": {"rtVerifyChid": "2"},
+	}
+	rtDevices, _, err = GetRTDevices(disks, &storeConfig, deviceConfigs)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, rtDevices[0].VerifyChid)
+	assert.Equal(t, 1, rtDevices[1].VerifyChid)
+	assert.Equal(t, 2, rtDevices[2].VerifyChid)
+
+	// match by by-path link name
+	deviceConfigs = map[string]map[string]string{
+		"pci-0000:00:1f.2-ata-sda": {"rtVerifyChid": "0"},
+	}
+	rtDevices, _, err = GetRTDevices(disks, &storeConfig, deviceConfigs)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, rtDevices[0].VerifyChid)
+	assert.Equal(t, 1, rtDevices[1].VerifyChid)
+	assert.Equal(t, 1, rtDevices[2].VerifyChid)
+}
+
+func TestGetRTDevicesPartialDeviceConfig(t *testing.T) {
+	disks := []sys.LocalDisk{testHDD("sda"), testHDD("sdb"), testSSD("sdc")}
+	storeConfig := config.DefaultStoreConfig()
+	storeConfig.Sync = 2
+	storeConfig.RtVerifyChid = 2
+	deviceConfigs := map[string]map[string]string{
+		"sda": {"lmdbPageSize": "32768"},
+	}
+
+	// all-HDD mode
+	rtDevices, _, err := GetRTDevices(disks, &storeConfig, deviceConfigs)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(rtDevices))
+	assert.Equal(t, "/dev/sda", rtDevices[0].Device)
+	assert.Equal(t, 32768, rtDevices[0].Psize)
+	assert.Equal(t, 2, rtDevices[0].Sync)
+	assert.Equal(t, 2, rtDevices[0].VerifyChid)
+
+	// hybrid mode
+	storeConfig.UseMetadataOffload = true
+	storeConfig.UseBCache = true
+	storeConfig.BCacheBlockSize = 4096
+	deviceConfigs = map[string]map[string]string{
+		"sda": {"sync": "0"},
+	}
+	rtDevices, _, err = GetRTDevices(disks, &storeConfig, deviceConfigs)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rtDevices))
+	for _, rtdev := range rtDevices {
+		if rtdev.Device == "/dev/sda" {
+			assert.Equal(t, 0, rtdev.Sync)
+		} else {
+			assert.Equal(t, 2, rtdev.Sync)
+		}
+		assert.Equal(t, storeConfig.LmdbPageSize, rtdev.Psize)
+		assert.Equal(t, 2, rtdev.VerifyChid)
+		assert.Equal(t, "ata-SSD_sdc,0xff", rtdev.Metadata)
+		assert.Equal(t, 1, rtdev.Bcache)
+		assert.Equal(t, 4096, rtdev.BcacheBlockSize)
+	}
+}
+
+func TestGetRTDevicesAllHDDWithSSDs(t *testing.T) {
+	disks := []sys.LocalDisk{testHDD("sda"), testSSD("sdb"), testHDD("sdc"), testSSD("sdd")}
+	storeConfig := config.DefaultStoreConfig()
+
+	rtDevices, warnings, err := GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(warnings))
+	assert.Equal(t, 4, len(rtDevices))
+	assert.Equal(t, "/dev/sda", rtDevices[0].Device)
+	assert.Equal(t, "/dev/sdc", rtDevices[1].Device)
+	assert.Equal(t, "/dev/sdb", rtDevices[2].Device)
+	assert.Equal(t, "/dev/sdd", rtDevices[3].Device)
+	for _, rtdev := range rtDevices {
+		assert.Equal(t, "", rtdev.Journal)
+		assert.Equal(t, "", rtdev.Metadata)
+	}
+
+	// reserved SSDs are skipped with warnings
+	storeConfig.RtReserveSSDs = true
+	rtDevices, warnings, err = GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rtDevices))
+	assert.Equal(t, "/dev/sda", rtDevices[0].Device)
+	assert.Equal(t, "/dev/sdc", rtDevices[1].Device)
+	assert.Equal(t, []string{
+		"SSD /dev/sdb skipped, reserved with rtReserveSSDs=true",
+		"SSD /dev/sdd skipped, reserved with rtReserveSSDs=true",
+	}, warnings)
+}
+
+func TestGetRTDevicesJournalSSDCount(t *testing.T) {
+	disks := []sys.LocalDisk{testHDD("sda"), testHDD("sdb"), testHDD("sdc"), testHDD("sdd"),
+		testSSD("sde"), testSSD("sdf"), testSSD("sdg")}
+	storeConfig := config.DefaultStoreConfig()
+	storeConfig.UseMetadataOffload = true
+
+	// count == len(ssds), same as default
+	rtDevicesDefault, _, err := GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	storeConfig.RtJournalSSDCount = 3
+	rtDevices, _, err := GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, rtDevicesDefault, rtDevices)
+	assert.Equal(t, 4, len(rtDevices))
+
+	// count < len(ssds), remaining SSD becomes data device
+	storeConfig.RtJournalSSDCount = 2
+	rtDevices, _, err = GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(rtDevices))
+	assert.Equal(t, "ata-SSD_sde", rtDevices[0].Journal)
+	assert.Equal(t, "ata-SSD_sde", rtDevices[1].Journal)
+	assert.Equal(t, "ata-SSD_sdf", rtDevices[2].Journal)
+	assert.Equal(t, "ata-SSD_sdf", rtDevices[3].Journal)
+	assert.Equal(t, "/dev/sdg", rtDevices[4].Device)
+	assert.Equal(t, "", rtDevices[4].Journal)
+
+	// out of range
+	for _, count := range []int{-1, 4} {
+		storeConfig.RtJournalSSDCount = count
+		rtDevices, _, err = GetRTDevices(disks, &storeConfig, nil)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "rtJournalSSDCount")
+		assert.Equal(t, 0, len(rtDevices))
+	}
+}
+
+func TestGetIdDevLinkNamePreference(t *testing.T) {
+	assert.Equal(t, []string{"scsi-3", "scsi-2", "scsi-S", "scsi-1", "scsi-0", "scsi-", "ata-", "nvme-"}, idDevLinkPreference)
+	assert.Equal(t, 0, getIdDevLinkRank("scsi-35000c500a1b2c3d4"))
+	assert.Equal(t, 2, getIdDevLinkRank("scsi-SATA_ST4000NM0033-9ZM_Z1Z0ABCD"))
+	assert.Equal(t, 4, getIdDevLinkRank("scsi-0ATA_ST4000NM0033_Z1Z0ABCD"))
+	assert.Equal(t, 6, getIdDevLinkRank("ata-ST4000NM0033_Z1Z0ABCD"))
+	assert.Equal(t, 7, getIdDevLinkRank("nvme-Samsung_SSD_970_S4EWNX0M123456"))
+	assert.Equal(t, 8, getIdDevLinkRank("usb-Generic_Flash_Disk"))
+
+	links := []string{
+		"/dev/disk/by-id/wwn-0x5000c500a1b2c3d4",
+		"/dev/disk/by-id/usb-Generic_Flash_Disk",
+		"/dev/disk/by-id/nvme-Samsung_SSD_970_S4EWNX0M123456",
+		"/dev/disk/by-id/ata-ST4000NM0033_Z1Z0ABCD",
+		"/dev/disk/by-id/scsi-35000c500a1b2c3d4",
+		"/dev/disk/by-id/scsi-SATA_ST4000NM0033_Z1Z0ABCD",
+		"/dev/disk/by-path/pci-0000:00:1f.2-ata-1",
+	}
+	orders := [][]int{
+		{0, 1, 2, 3, 4, 5, 6},
+		{6, 5, 4, 3, 2, 1, 0},
+		{3, 0, 6, 2, 5, 1, 4},
+		{2, 4, 1, 6, 0, 5, 3},
+	}
+	for _, order := range orders {
+		shuffled := make([]string, 0, len(order))
+		for _, i := range order {
+			shuffled = append(shuffled, links[i])
+		}
+		name, err := getIdDevLinkName(strings.Join(shuffled, " "))
+		assert.Nil(t, err)
+		assert.Equal(t, "scsi-35000c500a1b2c3d4", name)
+	}
+
+	// ata- is preferred over nvme- and unknown prefixes
+	name, err := getIdDevLinkName(strings.Join([]string{links[2], links[1], links[3], links[0]}, " "))
+	assert.Nil(t, err)
+	assert.Equal(t, "ata-ST4000NM0033_Z1Z0ABCD", name)
+
+	// scsi- aliases of a SATA disk are ordered by identifier type, not alphabetically
+	sata := []string{
+		"/dev/disk/by-id/scsi-0ATA_ST4000NM0033_Z1Z0ABCD",
+		"/dev/disk/by-id/scsi-1ATA_ST4000NM0033-9ZM_Z1Z0ABCD",
+		"/dev/disk/by-id/scsi-SATA_ST4000NM0033-9ZM_Z1Z0ABCD",
+		"/dev/disk/by-id/ata-ST4000NM0033_Z1Z0ABCD",
+	}
+	name, err = getIdDevLinkName(strings.Join(sata, " "))
+	assert.Nil(t, err)
+	assert.Equal(t, "scsi-SATA_ST4000NM0033-9ZM_Z1Z0ABCD", name)
+	name, err = getIdDevLinkName(strings.Join(append(sata, "/dev/disk/by-id/scsi-35000c500a1b2c3d4"), " "))
+	assert.Nil(t, err)
+	assert.Equal(t, "scsi-35000c500a1b2c3d4", name)
+}
+
+func TestKeepDeployedRTDeviceNames(t *testing.T) {
+	sataDisk := func(name string, serial string, rotational bool) sys.LocalDisk {
+		return sys.LocalDisk{
+			Name: name,
+			DevLinks: "/dev/disk/by-id/ata-ST4000NM0033_" + serial + " /dev/disk/by-id/scsi-0ATA_ST4000NM0033_" + serial +
+				" /dev/disk/by-id/scsi-SATA_ST4000NM0033_" + serial + " /dev/disk/by-path/pci-0000:00:1f.2-ata-" + name,
+			Rotational: rotational,
+			Empty:      true,
+		}
+	}
+	disks := []sys.LocalDisk{sataDisk("sda", "A", true), sataDisk("sdb", "B", true), sataDisk("sdc", "C", false)}
+	storeConfig := config.DefaultStoreConfig()
+	storeConfig.UseMetadataOffload = true
+
+	rtDevices, _, err := GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rtDevices))
+	assert.Equal(t, "scsi-SATA_ST4000NM0033_A", rtDevices[0].Name)
+	assert.Equal(t, "scsi-SATA_ST4000NM0033_C", rtDevices[0].Journal)
+
+	// nothing deployed yet
+	assert.Equal(t, rtDevices, KeepDeployedRTDeviceNames(rtDevices, disks, nil))
+
+	// sda and the journal were deployed with their ata- names, sdb is new
+	deployed := []edgefsv1alpha1.RTDevice{{
+		Name:     "ata-ST4000NM0033_A",
+		Device:   "/dev/sda",
+		Journal:  "ata-ST4000NM0033_C",
+		Metadata: "ata-ST4000NM0033_C,0xff",
+	}}
+	rtDevices = KeepDeployedRTDeviceNames(rtDevices, disks, deployed)
+	assert.Equal(t, 2, len(rtDevices))
+	assert.Equal(t, "ata-ST4000NM0033_A", rtDevices[0].Name)
+	assert.Equal(t, "/dev/sda", rtDevices[0].Device)
+	assert.Equal(t, "scsi-SATA_ST4000NM0033_B", rtDevices[1].Name)
+	for _, rtdev := range rtDevices {
+		assert.Equal(t, "ata-ST4000NM0033_C", rtdev.Journal)
+		assert.Equal(t, "ata-ST4000NM0033_C,0xff", rtdev.Metadata)
+	}
+}
+
+func TestCreateQualifiedHeadlessServiceName(t *testing.T) {
+	assert.Equal(t, "rook-edgefs-target-0.rook-edgefs-target.rook-edgefs",
+		CreateQualifiedHeadlessServiceName(0, "rook-edgefs"))
+	assert.Equal(t, CreateQualifiedHeadlessServiceName(2, "rook-edgefs"),
+		CreateQualifiedHeadlessServiceNameWithDomain(2, appName, appName, "rook-edgefs", ""))
+
+	assert.Equal(t, "edgefs-1.edgefs-svc.rook-edgefs.svc.cluster.internal",
+		CreateQualifiedHeadlessServiceNameWithDomain(1, "edgefs", "edgefs-svc", "rook-edgefs", "cluster.internal"))
+	// leading dot is normalized
+	assert.Equal(t, "edgefs-1.edgefs-svc.rook-edgefs.svc.cluster.internal",
+		CreateQualifiedHeadlessServiceNameWithDomain(1, "edgefs", "edgefs-svc", "rook-edgefs", ".cluster.internal"))
+	// full service domain suffix is accepted too
+	assert.Equal(t, "edgefs-1.edgefs-svc.rook-edgefs.svc.cluster.local",
+		CreateQualifiedHeadlessServiceNameWithDomain(1, "edgefs", "edgefs-svc", "rook-edgefs", "svc.cluster.local"))
+	assert.Equal(t, "edgefs-1.edgefs-svc.rook-edgefs.svc.cluster.local",
+		CreateQualifiedHeadlessServiceNameWithDomain(1, "edgefs", "edgefs-svc", "rook-edgefs", ".svc.cluster.local"))
+}
+
+func TestGetRTDevicesBcacheTunables(t *testing.T) {
+	disks := []sys.LocalDisk{testHDD("sda"), testHDD("sdb"), testSSD("sdc")}
+	storeConfig := config.ToStoreConfig(map[string]string{
+		"useMetadataOffload":     "true",
+		"bcacheBlockSize":        "4Ki",
+		"bcacheWritebackPercent": "40",
+		"bcacheSequentialCutoff": "4Mi",
+	})
+
+	// bcache off, tunables not set
+	rtDevices, _, err := GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rtDevices))
+	for _, rtdev := range rtDevices {
+		assert.Equal(t, 0, rtdev.Bcache)
+		assert.Equal(t, 0, rtdev.BcacheBlockSize)
+		assert.Equal(t, 0, rtdev.BcacheWritebackPercent)
+		assert.Equal(t, 0, rtdev.BcacheSequentialCutoff)
+	}
+	raw, err := json.Marshal(rtDevices[0])
+	assert.Nil(t, err)
+	assert.False(t, strings.Contains(string(raw), "bcache_block_size"))
+	assert.False(t, strings.Contains(string(raw), "bcache_writeback_percent"))
+	assert.False(t, strings.Contains(string(raw), "bcache_sequential_cutoff"))
+
+	// bcache on, writeback percent only with write back cache
+	storeConfig.UseBCache = true
+	rtDevices, _, err = GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	for _, rtdev := range rtDevices {
+		assert.Equal(t, 1, rtdev.Bcache)
+		assert.Equal(t, 4096, rtdev.BcacheBlockSize)
+		assert.Equal(t, 0, rtdev.BcacheWritebackPercent)
+		assert.Equal(t, 4194304, rtdev.BcacheSequentialCutoff)
+	}
+
+	storeConfig.UseBCacheWB = true
+	rtDevices, _, err = GetRTDevices(disks, &storeConfig, nil)
+	assert.Nil(t, err)
+	for _, rtdev := range rtDevices {
+		assert.Equal(t, 0, rtdev.BcacheWritearound)
+		assert.Equal(t, 40, rtdev.BcacheWritebackPercent)
+	}
+}
+
+func TestGetRTDevicesDuplicateNames(t *testing.T) {
+	multipath := func(name string, rotational bool) sys.LocalDisk {
+		return sys.LocalDisk{
+			Name:       name,
+			DevLinks:   "/dev/disk/by-id/scsi-35000c500a1b2c3d4 /dev/disk/by-id/wwn-0x5000c500a1b2c3d4",
+			Rotational: rotational,
+			Empty:      true,
+		}
+	}
+
+	// all-HDD
+	storeConfig := config.DefaultStoreConfig()
+	disks := []sys.LocalDisk{multipath("sda", true), multipath("sdb", true), testHDD("sdc")}
+	rtDevices, _, err := GetRTDevices(disks, &storeConfig, nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, "duplicate RTDevice names found: scsi-35000c500a1b2c3d4 (/dev/sda, /dev/sdb)", err.Error())
+	assert.Equal(t, 0, len(rtDevices))
+	layout, err := DescribeLayout(disks, &storeConfig)
+	assert.NotNil(t, err)
+	assert.Equal(t, "duplicate RTDevice names found: scsi-35000c500a1b2c3d4 (/dev/sda, /dev/sdb)", err.Error())
+	assert.Equal(t, "", layout)
+
+	// hybrid
+	storeConfig.UseMetadataOffload = true
+	disks = append(disks, testSSD("sdd"))
+	_, _, err = GetRTDevices(disks, &storeConfig, nil)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "/dev/sda, /dev/sdb")
+	_, err = DescribeLayout(disks, &storeConfig)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "/dev/sda, /dev/sdb")
+
+	// all-SSD
+	storeConfig = config.DefaultStoreConfig()
+	storeConfig.UseAllSSD = true
+	disks = []sys.LocalDisk{multipath("sda", false), multipath("sdb", false)}
+	_, _, err = GetRTDevices(disks, &storeConfig, nil)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "/dev/sda, /dev/sdb")
+	_, err = DescribeLayout(disks, &storeConfig)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "/dev/sda, /dev/sdb")
+
+	// hybrid with a multipath SSD used as two journals
+	storeConfig = config.DefaultStoreConfig()
+	storeConfig.UseMetadataOffload = true
+	disks = []sys.LocalDisk{testHDD("sda"), testHDD("sdb"), multipath("sdc", false), multipath("sdd", false)}
+	rtDevices, _, err = GetRTDevices(disks, &storeConfig, nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, "duplicate RTDevice names found: scsi-35000c500a1b2c3d4 (/dev/sdc, /dev/sdd)", err.Error())
+	assert.Equal(t, 0, len(rtDevices))
+	_, err = DescribeLayout(disks, &storeConfig)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "/dev/sdc, /dev/sdd")
+}
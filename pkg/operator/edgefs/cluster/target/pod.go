@@ -199,7 +199,10 @@ func (c *Cluster) makeDaemonContainer(containerImage string, dro edgefsv1alpha1.
 	if c.deploymentConfig.DeploymentType == edgefsv1alpha1.DeploymentAutoRtlfs {
 		volumeMounts = append(volumeMounts, v1.VolumeMount{Name: dataVolumeName, MountPath: "/data"})
 	} else if c.deploymentConfig.DeploymentType == edgefsv1alpha1.DeploymentRtlfs {
-		rtlfsDevices := GetRtlfsDevices(c.Storage.Directories, &clusterStorageConfig)
+		rtlfsDevices, err := GetRtlfsDevices(c.Storage.Directories, &clusterStorageConfig)
+		if err != nil {
+			logger.Warningf("failed to get rtlfs devices: %v", err)
+		}
 		for _, device := range rtlfsDevices {
 			volumeMounts = append(volumeMounts, v1.VolumeMount{Name: device.Name, MountPath: device.Path})
 		}
@@ -90,15 +90,19 @@ func (c *cluster) getClusterNodes() ([]rookalpha.Node, error) {
 func (c *cluster) createDeploymentConfig(nodes []rookalpha.Node, resurrect bool) (edgefsv1alpha1.ClusterDeploymentConfig, error) {
 
 	deploymentConfig := edgefsv1alpha1.ClusterDeploymentConfig{DevConfig: make(map[string]edgefsv1alpha1.DevicesConfig, 0)}
+	deployedRTDevices := c.getDeployedRTDevices()
 	//Fill deploymentConfig devices struct
 	for _, node := range nodes {
 		n := c.resolveNode(node.Name)
-		storeConfig := config.ToStoreConfig(n.Config)
-
 		if n == nil {
 			return deploymentConfig, fmt.Errorf("node %s did not resolve to start target", node.Name)
 		}
 
+		storeConfig := config.ToStoreConfig(n.Config)
+		if err := config.ValidateStoreConfig(&storeConfig); err != nil {
+			return deploymentConfig, fmt.Errorf("invalid storage config for node %s: %v", n.Name, err)
+		}
+
 		devicesConfig := edgefsv1alpha1.DevicesConfig{}
 		devicesConfig.Rtrd.Devices = make([]edgefsv1alpha1.RTDevice, 0)
 		devicesConfig.Rtlfs.Devices = make([]edgefsv1alpha1.RtlfsDevice, 0)
@@ -136,14 +140,38 @@ func (c *cluster) createDeploymentConfig(nodes []rookalpha.Node, resurrect bool)
 			}
 		}
 
-		rtDevices, err := target.GetRTDevices(availDisks, &storeConfig)
+		// Device specific config settings override node's ones
+		deviceConfigs := make(map[string]map[string]string)
+		for _, dev := range n.Devices {
+			if len(dev.Config) > 0 {
+				if err := config.ValidateDeviceConfig(dev.Config); err != nil {
+					return deploymentConfig, fmt.Errorf("invalid storage config for device %s on node %s: %v", dev.Name, n.Name, err)
+				}
+				deviceConfig := config.ApplyStoreConfig(storeConfig, dev.Config)
+				if err := config.ValidateStoreConfig(&deviceConfig); err != nil {
+					return deploymentConfig, fmt.Errorf("invalid storage config for device %s on node %s: %v", dev.Name, n.Name, err)
+				}
+				deviceConfigs[dev.Name] = dev.Config
+			}
+		}
+
+		rtDevices, warnings, err := target.GetRTDevices(availDisks, &storeConfig, deviceConfigs)
 		if err != nil {
 			logger.Warningf("Can't get rtDevices for node %s due %v", n.Name, err)
 			rtDevices = make([]edgefsv1alpha1.RTDevice, 0)
 		}
+		rtDevices = target.KeepDeployedRTDeviceNames(rtDevices, availDisks, deployedRTDevices[n.Name])
+		for _, warning := range warnings {
+			logger.Warningf("node %s in namespace %s: %s", n.Name, c.Namespace, warning)
+		}
+
+		rtlfsDevices, err := target.GetRtlfsDevices(c.Spec.Storage.Directories, &storeConfig)
+		if err != nil {
+			return deploymentConfig, fmt.Errorf("failed to get rtlfs devices for node %s: %v", n.Name, err)
+		}
 
 		devicesConfig.Rtrd.Devices = rtDevices
-		devicesConfig.Rtlfs.Devices = target.GetRtlfsDevices(c.Spec.Storage.Directories, &storeConfig)
+		devicesConfig.Rtlfs.Devices = rtlfsDevices
 		deploymentConfig.DevConfig[node.Name] = devicesConfig
 	}
 
@@ -153,7 +181,13 @@ func (c *cluster) createDeploymentConfig(nodes []rookalpha.Node, resurrect bool)
 	}
 	// Add Directories to deploymentConfig
 	clusterStorageConfig := config.ToStoreConfig(c.Spec.Storage.Config)
-	deploymentConfig.Directories = target.GetRtlfsDevices(c.Spec.Storage.Directories, &clusterStorageConfig)
+	if err := config.ValidateStoreConfig(&clusterStorageConfig); err != nil {
+		return deploymentConfig, fmt.Errorf("invalid cluster storage config: %v", err)
+	}
+	deploymentConfig.Directories, err = target.GetRtlfsDevices(c.Spec.Storage.Directories, &clusterStorageConfig)
+	if err != nil {
+		return deploymentConfig, err
+	}
 
 	if len(c.Spec.Storage.Directories) > 0 && (len(c.Spec.DataDirHostPath) > 0 || c.Spec.DataVolumeSize.Value() != 0) {
 		deploymentConfig.DeploymentType = edgefsv1alpha1.DeploymentRtlfs